@@ -5,12 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/pentops/log.go/log"
 	"github.com/pentops/o5-github-lambda/internal/github"
 	sceb "github.com/pentops/o5-runtime-sidecar/adapters/eventbridge"
@@ -33,6 +37,18 @@ func main() {
 
 type Secret struct {
 	GithubWebhookSecret string `json:"githubWebhookSecret"`
+
+	// GithubAppID and GithubAppPrivateKey are optional: when both are set,
+	// the worker mints GitHub App installation tokens for downstream API
+	// calls. When either is missing, app auth is disabled.
+	GithubAppID         string `json:"githubAppId"`
+	GithubAppPrivateKey string `json:"githubAppPrivateKey"`
+
+	// GitlabWebhookSecret and GiteaWebhookSecret are optional: when set,
+	// the corresponding provider is registered alongside github so this
+	// Lambda can also receive webhooks from that SCM.
+	GitlabWebhookSecret string `json:"gitlabWebhookSecret"`
+	GiteaWebhookSecret  string `json:"giteaWebhookSecret"`
 }
 
 func do(ctx context.Context) error {
@@ -90,11 +106,61 @@ func do(ctx context.Context) error {
 	}
 	publishers = append(publishers, eventBridgePublisher)
 
-	webhook, err := github.NewWebhookWorker(secretVal.GithubWebhookSecret, sourceConfig, publishers...)
+	if snsTopicPrefix := os.Getenv("TARGET_SNS_TOPIC_PREFIX"); snsTopicPrefix != "" {
+		snsClient := sns.NewFromConfig(awsConfig)
+		publishers = append(publishers, github.NewSNSFanoutPublisher(snsClient, snsTopicPrefix+"{event}"))
+	}
+
+	var appClient *github.GitHubAppClient
+	if secretVal.GithubAppID != "" && secretVal.GithubAppPrivateKey != "" {
+		appClient, err = github.NewGitHubAppClient(secretVal.GithubAppID, []byte(secretVal.GithubAppPrivateKey))
+		if err != nil {
+			return fmt.Errorf("building github app client: %w", err)
+		}
+	}
+
+	var trustPolicy *github.TrustPolicy
+	if trustPolicyJSON := os.Getenv("TRUST_POLICY_JSON"); trustPolicyJSON != "" {
+		trustPolicy, err = github.LoadTrustPolicyJSON([]byte(trustPolicyJSON))
+		if err != nil {
+			return fmt.Errorf("loading trust policy from TRUST_POLICY_JSON: %w", err)
+		}
+	} else if trustPolicyS3URI := os.Getenv("TRUST_POLICY_S3_URI"); trustPolicyS3URI != "" {
+		s3Client := s3.NewFromConfig(awsConfig)
+		trustPolicy, err = github.LoadTrustPolicyFromS3(ctx, s3Client, trustPolicyS3URI)
+		if err != nil {
+			return fmt.Errorf("loading trust policy from %s: %w", trustPolicyS3URI, err)
+		}
+	}
+
+	var auditPublisher github.Publisher
+	if rejectedEventBusARN := os.Getenv("REJECTED_WEBHOOK_EVENT_BUS_ARN"); rejectedEventBusARN != "" {
+		auditPublisher, err = sceb.NewEventBridgePublisher(eventBridgeClient, sceb.EventBridgeConfig{
+			BusARN: rejectedEventBusARN,
+		})
+		if err != nil {
+			return fmt.Errorf("creating rejected webhook publisher: %w", err)
+		}
+	}
+
+	webhook, err := github.NewWebhookWorker(secretVal.GithubWebhookSecret, sourceConfig, appClient, trustPolicy, auditPublisher, publishers...)
 	if err != nil {
 		return err
 	}
 
+	if dedupTable := os.Getenv("DELIVERY_DEDUP_TABLE"); dedupTable != "" {
+		dedupTTL := 7 * 24 * time.Hour
+		dynamoClient := dynamodb.NewFromConfig(awsConfig)
+		webhook.Dedup = github.NewDeliveryDedup(dynamoClient, dedupTable, dedupTTL)
+	}
+
+	if secretVal.GitlabWebhookSecret != "" {
+		webhook.RegisterProvider("gitlab", github.NewGitLabProvider(secretVal.GitlabWebhookSecret))
+	}
+	if secretVal.GiteaWebhookSecret != "" {
+		webhook.RegisterProvider("gitea", github.NewGiteaProvider(secretVal.GiteaWebhookSecret))
+	}
+
 	lambda.Start(webhook.HandleLambda)
 	return nil
 }
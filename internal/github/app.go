@@ -0,0 +1,129 @@
+package github
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// jwtClockSkew backdates the JWT's issued-at time to tolerate clock drift
+	// between us and GitHub's servers, as recommended by GitHub's App auth docs.
+	jwtClockSkew = 60 * time.Second
+	// jwtTTL is the App JWT lifetime. GitHub rejects anything over 10 minutes.
+	jwtTTL = 10 * time.Minute
+	// tokenRefreshWindow is how far ahead of expiry a cached installation
+	// token is considered stale and re-minted.
+	tokenRefreshWindow = 5 * time.Minute
+)
+
+// GitHubAppClient mints and caches GitHub App installation access tokens, so
+// publishers can call the GitHub API as the app without each holding the
+// app's private key or re-authenticating per request.
+type GitHubAppClient struct {
+	appID      string
+	privateKey *rsa.PrivateKey
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	tokens map[int64]installationToken
+}
+
+type installationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// NewGitHubAppClient builds a client which authenticates as the GitHub App
+// identified by appID, signing JWTs with privateKeyPEM (the App's PEM
+// encoded RSA private key, as downloaded from the App's settings page).
+func NewGitHubAppClient(appID string, privateKeyPEM []byte) (*GitHubAppClient, error) {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("parsing app private key: %w", err)
+	}
+
+	return &GitHubAppClient{
+		appID:      appID,
+		privateKey: key,
+		httpClient: http.DefaultClient,
+		tokens:     map[int64]installationToken{},
+	}, nil
+}
+
+// InstallationToken returns a token authenticated as the given installation,
+// minting a new one via the GitHub API if there is no cached token or the
+// cached one is within tokenRefreshWindow of expiring.
+func (c *GitHubAppClient) InstallationToken(ctx context.Context, installationID int64) (string, error) {
+	c.mu.Lock()
+	cached, ok := c.tokens[installationID]
+	c.mu.Unlock()
+	if ok && time.Until(cached.expiresAt) > tokenRefreshWindow {
+		return cached.token, nil
+	}
+
+	token, expiresAt, err := c.mintInstallationToken(ctx, installationID)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.tokens[installationID] = installationToken{token: token, expiresAt: expiresAt}
+	c.mu.Unlock()
+
+	return token, nil
+}
+
+func (c *GitHubAppClient) appJWT() (string, error) {
+	issuedAt := time.Now().Add(-jwtClockSkew)
+	claims := jwt.RegisteredClaims{
+		IssuedAt:  jwt.NewNumericDate(issuedAt),
+		ExpiresAt: jwt.NewNumericDate(issuedAt.Add(jwtTTL)),
+		Issuer:    c.appID,
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(c.privateKey)
+}
+
+type installationAccessTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (c *GitHubAppClient) mintInstallationToken(ctx context.Context, installationID int64) (string, time.Time, error) {
+	appJWT, err := c.appJWT()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("signing app jwt: %w", err)
+	}
+
+	url := fmt.Sprintf("https://api.github.com/app/installations/%d/access_tokens", installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("building installation token request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+appJWT)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("requesting installation token: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return "", time.Time{}, fmt.Errorf("unexpected status minting installation token: %s", res.Status)
+	}
+
+	tokenResp := &installationAccessTokenResponse{}
+	if err := json.NewDecoder(res.Body).Decode(tokenResp); err != nil {
+		return "", time.Time{}, fmt.Errorf("decoding installation token response: %w", err)
+	}
+
+	return tokenResp.Token, tokenResp.ExpiresAt, nil
+}
@@ -0,0 +1,111 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBPutItemAPI is the subset of the DynamoDB client used for
+// deduplication, so callers can pass the real client or a fake in tests.
+type DynamoDBPutItemAPI interface {
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+}
+
+// deliveryIDAttribute, expiresAtAttribute and statusAttribute are the
+// DynamoDB item's attribute names. The table must have deliveryIDAttribute
+// as its partition key, and a TTL configured on expiresAtAttribute to expire
+// old entries.
+const (
+	deliveryIDAttribute = "deliveryId"
+	expiresAtAttribute  = "expiresAt"
+	statusAttribute     = "status"
+)
+
+// statusPending marks a delivery that has been claimed but not yet
+// published; statusCommitted marks one that was. Only committed deliveries
+// are treated as duplicates, so a delivery that was claimed but never
+// published (its handler crashed, its publish failed, DynamoDB itself
+// partially wrote) is picked up again by the next redelivery instead of
+// being silently dropped forever.
+const (
+	statusPending   = "pending"
+	statusCommitted = "committed"
+)
+
+// DeliveryDedup makes webhook delivery idempotent by recording each
+// delivery ID in DynamoDB before it is published, so a redelivery of the
+// same webhook (e.g. GitHub retrying after a Lambda timeout) is detected
+// and skipped rather than published again.
+type DeliveryDedup struct {
+	client DynamoDBPutItemAPI
+	table  string
+	ttl    time.Duration
+}
+
+// NewDeliveryDedup builds a dedup layer backed by the given DynamoDB table.
+// Entries are recorded with a TTL of ttl, after which DynamoDB expires them
+// and the delivery ID may be reused.
+func NewDeliveryDedup(client DynamoDBPutItemAPI, table string, ttl time.Duration) *DeliveryDedup {
+	return &DeliveryDedup{client: client, table: table, ttl: ttl}
+}
+
+// CheckAndRecord conditionally claims deliveryID as pending. It returns
+// duplicate=true when deliveryID was already committed (published) and so
+// should not be published again. Otherwise it returns false, having claimed
+// (or re-claimed) the delivery ID as pending; the caller must call
+// MarkCommitted once the delivery has actually been published, or the next
+// redelivery will claim it again rather than treat it as a duplicate.
+func (d *DeliveryDedup) CheckAndRecord(ctx context.Context, deliveryID string) (duplicate bool, err error) {
+	expiresAt := strconv.FormatInt(time.Now().Add(d.ttl).Unix(), 10)
+
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.table),
+		Item: map[string]types.AttributeValue{
+			deliveryIDAttribute: &types.AttributeValueMemberS{Value: deliveryID},
+			expiresAtAttribute:  &types.AttributeValueMemberN{Value: expiresAt},
+			statusAttribute:     &types.AttributeValueMemberS{Value: statusPending},
+		},
+		ConditionExpression: aws.String(fmt.Sprintf("attribute_not_exists(%s) OR %s <> :committed", deliveryIDAttribute, statusAttribute)),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":committed": &types.AttributeValueMemberS{Value: statusCommitted},
+		},
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return true, nil
+		}
+		return false, fmt.Errorf("recording delivery %s: %w", deliveryID, err)
+	}
+
+	return false, nil
+}
+
+// MarkCommitted marks a delivery ID previously claimed by CheckAndRecord as
+// committed, so later redeliveries of the same ID are treated as
+// duplicates. Call this only after the delivery has actually been
+// published.
+func (d *DeliveryDedup) MarkCommitted(ctx context.Context, deliveryID string) error {
+	expiresAt := strconv.FormatInt(time.Now().Add(d.ttl).Unix(), 10)
+
+	_, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.table),
+		Item: map[string]types.AttributeValue{
+			deliveryIDAttribute: &types.AttributeValueMemberS{Value: deliveryID},
+			expiresAtAttribute:  &types.AttributeValueMemberN{Value: expiresAt},
+			statusAttribute:     &types.AttributeValueMemberS{Value: statusCommitted},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("committing delivery %s: %w", deliveryID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package github
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// fakeDynamoDBPutItemAPI is an in-memory DynamoDBPutItemAPI which honours
+// the same attribute_not_exists/status conditional logic as real DynamoDB,
+// keyed on deliveryIDAttribute.
+type fakeDynamoDBPutItemAPI struct {
+	items map[string]map[string]types.AttributeValue
+}
+
+func newFakeDynamoDBPutItemAPI() *fakeDynamoDBPutItemAPI {
+	return &fakeDynamoDBPutItemAPI{items: map[string]map[string]types.AttributeValue{}}
+}
+
+func (f *fakeDynamoDBPutItemAPI) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	key := params.Item[deliveryIDAttribute].(*types.AttributeValueMemberS).Value
+
+	existing, exists := f.items[key]
+	if params.ConditionExpression != nil {
+		// The only conditions CheckAndRecord/MarkCommitted issue are
+		// "attribute_not_exists(deliveryId) OR status <> :committed".
+		if exists {
+			status := existing[statusAttribute].(*types.AttributeValueMemberS).Value
+			if status == statusCommitted {
+				return nil, &types.ConditionalCheckFailedException{}
+			}
+		}
+	}
+
+	f.items[key] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDBPutItemAPI) status(deliveryID string) (string, bool) {
+	item, ok := f.items[deliveryID]
+	if !ok {
+		return "", false
+	}
+	return item[statusAttribute].(*types.AttributeValueMemberS).Value, true
+}
+
+func TestDeliveryDedupCheckAndRecord(t *testing.T) {
+	client := newFakeDynamoDBPutItemAPI()
+	dedup := NewDeliveryDedup(client, "deliveries", 24*time.Hour)
+	ctx := context.Background()
+
+	duplicate, err := dedup.CheckAndRecord(ctx, "delivery-1")
+	if err != nil {
+		t.Fatalf("unexpected error on first sight: %v", err)
+	}
+	if duplicate {
+		t.Fatalf("expected first sight of a delivery to not be a duplicate")
+	}
+	if status, _ := client.status("delivery-1"); status != statusPending {
+		t.Fatalf("status after CheckAndRecord = %q, want %q", status, statusPending)
+	}
+
+	// A redelivery before the publish ever committed must still be let
+	// through, not swallowed as a duplicate.
+	duplicate, err = dedup.CheckAndRecord(ctx, "delivery-1")
+	if err != nil {
+		t.Fatalf("unexpected error on pending redelivery: %v", err)
+	}
+	if duplicate {
+		t.Fatalf("a pending (uncommitted) delivery must not be treated as a duplicate")
+	}
+
+	if err := dedup.MarkCommitted(ctx, "delivery-1"); err != nil {
+		t.Fatalf("unexpected error marking committed: %v", err)
+	}
+
+	duplicate, err = dedup.CheckAndRecord(ctx, "delivery-1")
+	if err != nil {
+		t.Fatalf("unexpected error on committed redelivery: %v", err)
+	}
+	if !duplicate {
+		t.Fatalf("a committed delivery must be treated as a duplicate")
+	}
+}
+
+func TestDeliveryDedupCheckAndRecordDistinctDeliveries(t *testing.T) {
+	client := newFakeDynamoDBPutItemAPI()
+	dedup := NewDeliveryDedup(client, "deliveries", time.Hour)
+	ctx := context.Background()
+
+	if err := dedup.MarkCommitted(ctx, "delivery-a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	duplicate, err := dedup.CheckAndRecord(ctx, "delivery-b")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if duplicate {
+		t.Fatalf("a different delivery ID must not be treated as a duplicate of an unrelated committed one")
+	}
+}
@@ -0,0 +1,90 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func TestGitLabProviderVerifySignature(t *testing.T) {
+	provider := NewGitLabProvider("s3cr3t")
+	body := []byte(`{"object_kind":"push"}`)
+
+	cases := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"correct token", "s3cr3t", false},
+		{"wrong token", "wrong", true},
+		{"missing token", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			headers := http.Header{}
+			if tc.token != "" {
+				headers.Set("X-Gitlab-Token", tc.token)
+			}
+
+			payload, err := provider.VerifySignature(headers, body)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(payload) != string(body) {
+				t.Fatalf("payload = %q, want %q", payload, body)
+			}
+		})
+	}
+}
+
+func TestGiteaProviderVerifySignature(t *testing.T) {
+	secret := []byte("s3cr3t")
+	provider := NewGiteaProvider(string(secret))
+	body := []byte(`{"action":"opened"}`)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	validSignature := hex.EncodeToString(mac.Sum(nil))
+
+	cases := []struct {
+		name      string
+		signature string
+		wantErr   bool
+	}{
+		{"correct signature", validSignature, false},
+		{"wrong signature", "00112233445566778899aabbccddeeff0011223344556677889900aabbccdd", true},
+		{"missing signature", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			headers := http.Header{}
+			if tc.signature != "" {
+				headers.Set("X-Gitea-Signature", tc.signature)
+			}
+
+			payload, err := provider.VerifySignature(headers, body)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(payload) != string(body) {
+				t.Fatalf("payload = %q, want %q", payload, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/pentops/log.go/log"
+	"github.com/pentops/o5-messaging/gen/o5/messaging/v1/messaging_pb"
+)
+
+// sanitizedEventTypePattern is the character set a sanitized event type must
+// match before it's substituted into an SNS topic ARN. Provider event kind
+// names aren't signed payload content - gitlab authenticates with a shared
+// token and gitea signs only the body, not the headers - so letting the raw
+// header value through unsanitized would let anyone holding that token
+// choose which topic ARN gets published to.
+var sanitizedEventTypePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// nonARNSafeChar matches any character not allowed in a sanitized event
+// type, so it can be collapsed to "_".
+var nonARNSafeChar = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// sanitizeEventType normalizes a provider's event kind (e.g. GitHub's
+// "push", GitLab's "Push Hook") into an SNS/ARN-safe token, so real
+// provider vocabulary isn't rejected outright while still refusing to
+// substitute arbitrary characters into a topic ARN.
+func sanitizeEventType(eventType string) string {
+	return nonARNSafeChar.ReplaceAllString(strings.ToLower(eventType), "_")
+}
+
+// SNSAPI is the subset of the SNS client used for fanout, so callers can
+// pass the real client or a fake in tests.
+type SNSAPI interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// SNSFanoutPublisher publishes each message to a per-event-type SNS topic,
+// so subscribers can filter on event type without receiving every webhook.
+// The topic ARN is built from topicARNTemplate by substituting "{event}"
+// with the message's event_type header as normalized by sanitizeEventType
+// (e.g. "Push Hook" becomes "push_hook"), so operators can set up SNS
+// filter policies and subscriptions per event rather than per message.
+type SNSFanoutPublisher struct {
+	client           SNSAPI
+	topicARNTemplate string
+}
+
+// NewSNSFanoutPublisher builds a publisher which sends to topics named by
+// substituting "{event}" in topicARNTemplate with each message's sanitized
+// event type, e.g. "arn:aws:sns:us-east-1:123456789012:github-{event}".
+func NewSNSFanoutPublisher(client SNSAPI, topicARNTemplate string) *SNSFanoutPublisher {
+	return &SNSFanoutPublisher{
+		client:           client,
+		topicARNTemplate: topicARNTemplate,
+	}
+}
+
+func (p *SNSFanoutPublisher) PublisherID() string {
+	return p.topicARNTemplate
+}
+
+func (p *SNSFanoutPublisher) Publish(ctx context.Context, message *messaging_pb.Message) error {
+	eventType := message.Headers["event_type"]
+	sanitized := sanitizeEventType(eventType)
+	if !sanitizedEventTypePattern.MatchString(sanitized) {
+		return fmt.Errorf("refusing to publish: invalid event type %q", eventType)
+	}
+	topicARN := strings.Replace(p.topicARNTemplate, "{event}", sanitized, 1)
+
+	attributes := map[string]types.MessageAttributeValue{}
+	for _, key := range []string{"repo", "owner", "ref", "event_type"} {
+		val := message.Headers[key]
+		if val == "" {
+			continue
+		}
+		attributes[key] = types.MessageAttributeValue{
+			StringValue: aws.String(val),
+			DataType:    aws.String("String"),
+		}
+	}
+
+	_, err := p.client.Publish(ctx, &sns.PublishInput{
+		TopicArn:          aws.String(topicARN),
+		Message:           aws.String(string(message.Body.Value)),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		return fmt.Errorf("publishing %s to %s: %w", eventType, topicARN, err)
+	}
+
+	log.WithFields(ctx, map[string]interface{}{
+		"topicArn":  topicARN,
+		"eventType": eventType,
+	}).Info("Published to SNS")
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package github
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/pentops/o5-messaging/gen/o5/messaging/v1/messaging_pb"
+)
+
+type fakeSNSAPI struct {
+	lastInput *sns.PublishInput
+	err       error
+}
+
+func (f *fakeSNSAPI) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.lastInput = params
+	return &sns.PublishOutput{}, nil
+}
+
+func TestSanitizeEventType(t *testing.T) {
+	cases := []struct {
+		eventType string
+		want      string
+	}{
+		{"push", "push"},
+		{"Push Hook", "push_hook"},
+		{"Tag Push Hook", "tag_push_hook"},
+		{"Merge Request Hook", "merge_request_hook"},
+		{"issue_comment", "issue_comment"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.eventType, func(t *testing.T) {
+			got := sanitizeEventType(tc.eventType)
+			if got != tc.want {
+				t.Fatalf("sanitizeEventType(%q) = %q, want %q", tc.eventType, got, tc.want)
+			}
+			if !sanitizedEventTypePattern.MatchString(got) {
+				t.Fatalf("sanitizeEventType(%q) = %q, which doesn't match sanitizedEventTypePattern", tc.eventType, got)
+			}
+		})
+	}
+}
+
+func TestSNSFanoutPublisherPublish(t *testing.T) {
+	client := &fakeSNSAPI{}
+	publisher := NewSNSFanoutPublisher(client, "arn:aws:sns:us-east-1:123456789012:github-{event}")
+
+	message := &messaging_pb.Message{
+		Body: &messaging_pb.Any{Value: []byte(`{"ok":true}`)},
+		Headers: map[string]string{
+			"event_type": "Push Hook",
+			"repo":       "myorg/widgets",
+			"owner":      "myorg",
+			"ref":        "refs/heads/main",
+		},
+	}
+
+	if err := publisher.Publish(context.Background(), message); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantARN := "arn:aws:sns:us-east-1:123456789012:github-push_hook"
+	if got := *client.lastInput.TopicArn; got != wantARN {
+		t.Fatalf("TopicArn = %q, want %q", got, wantARN)
+	}
+
+	for _, key := range []string{"repo", "owner", "ref", "event_type"} {
+		attr, ok := client.lastInput.MessageAttributes[key]
+		if !ok {
+			t.Fatalf("missing message attribute %q", key)
+		}
+		if *attr.StringValue != message.Headers[key] {
+			t.Fatalf("message attribute %q = %q, want %q", key, *attr.StringValue, message.Headers[key])
+		}
+	}
+}
+
+func TestSNSFanoutPublisherPublishRejectsEmptyEventType(t *testing.T) {
+	client := &fakeSNSAPI{}
+	publisher := NewSNSFanoutPublisher(client, "arn:aws:sns:us-east-1:123456789012:github-{event}")
+
+	message := &messaging_pb.Message{
+		Body:    &messaging_pb.Any{Value: []byte(`{}`)},
+		Headers: map[string]string{},
+	}
+
+	if err := publisher.Publish(context.Background(), message); err == nil {
+		t.Fatalf("expected an error for a missing event type, got none")
+	}
+	if client.lastInput != nil {
+		t.Fatalf("expected no SNS publish call for an invalid event type")
+	}
+}
@@ -0,0 +1,150 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// TrustRule allowlists a repo/ref combination. Repo and Refs are glob
+// patterns matched with path.Match, e.g. "myorg/*" or "refs/heads/release/*".
+// RequiredStatusChecks, if set, restricts matching check_run events to the
+// named checks; it has no effect on push or check_suite events, which carry
+// no check name.
+type TrustRule struct {
+	Repo                 string   `json:"repo"`
+	Refs                 []string `json:"refs"`
+	RequiredStatusChecks []string `json:"requiredStatusChecks,omitempty"`
+}
+
+// TrustPolicyConfig is the JSON document loaded from S3 or an env-provided
+// blob to build a TrustPolicy.
+type TrustPolicyConfig struct {
+	Rules []TrustRule `json:"rules"`
+}
+
+// TrustPolicy decides whether a repo+ref is allowed to publish downstream.
+// A policy with no rules allows everything, so that trust enforcement is
+// opt-in per deployment.
+type TrustPolicy struct {
+	rules []TrustRule
+}
+
+func NewTrustPolicy(config TrustPolicyConfig) *TrustPolicy {
+	return &TrustPolicy{rules: config.Rules}
+}
+
+// LoadTrustPolicyJSON parses a trust policy document, e.g. from an
+// env-provided JSON blob.
+func LoadTrustPolicyJSON(raw []byte) (*TrustPolicy, error) {
+	config := TrustPolicyConfig{}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("parsing trust policy: %w", err)
+	}
+	return NewTrustPolicy(config), nil
+}
+
+// S3GetObjectAPI is the subset of the S3 client used to fetch a trust
+// policy document, so callers can pass the real client or a fake in tests.
+type S3GetObjectAPI interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// LoadTrustPolicyFromS3 fetches and parses a trust policy document from
+// an "s3://bucket/key" URI.
+func LoadTrustPolicyFromS3(ctx context.Context, client S3GetObjectAPI, s3URI string) (*TrustPolicy, error) {
+	bucket, key, err := parseS3URI(s3URI)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching trust policy %s: %w", s3URI, err)
+	}
+	defer out.Body.Close()
+
+	raw, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading trust policy %s: %w", s3URI, err)
+	}
+
+	return LoadTrustPolicyJSON(raw)
+}
+
+func parseS3URI(s3URI string) (bucket, key string, err error) {
+	const prefix = "s3://"
+	if !strings.HasPrefix(s3URI, prefix) {
+		return "", "", fmt.Errorf("invalid s3 uri %q: must start with %s", s3URI, prefix)
+	}
+	rest := strings.TrimPrefix(s3URI, prefix)
+	bucket, key, ok := strings.Cut(rest, "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid s3 uri %q: expected s3://bucket/key", s3URI)
+	}
+	return bucket, key, nil
+}
+
+// Evaluate checks repo (in "owner/name" form) and ref against the
+// configured rules. When statusCheck is non-empty, a matching rule's
+// RequiredStatusChecks (if any) must include it. It returns true when
+// allowed, or false with a human-readable reason when rejected.
+func (tp *TrustPolicy) Evaluate(repo, ref, statusCheck string) (bool, string) {
+	if len(tp.rules) == 0 {
+		return true, ""
+	}
+
+	matchedRepo := false
+	for _, rule := range tp.rules {
+		repoOK, err := path.Match(rule.Repo, repo)
+		if err != nil || !repoOK {
+			continue
+		}
+		matchedRepo = true
+
+		if !matchesAny(rule.Refs, ref) {
+			continue
+		}
+
+		if statusCheck != "" && len(rule.RequiredStatusChecks) > 0 && !contains(rule.RequiredStatusChecks, statusCheck) {
+			continue
+		}
+
+		return true, ""
+	}
+
+	if !matchedRepo {
+		return false, fmt.Sprintf("repo %q does not match any trust policy rule", repo)
+	}
+	return false, fmt.Sprintf("ref %q on repo %q is not permitted by trust policy", ref, repo)
+}
+
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
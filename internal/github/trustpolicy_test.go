@@ -0,0 +1,49 @@
+package github
+
+import "testing"
+
+func TestTrustPolicyEvaluate(t *testing.T) {
+	policy := NewTrustPolicy(TrustPolicyConfig{
+		Rules: []TrustRule{{
+			Repo:                 "myorg/*",
+			Refs:                 []string{"refs/heads/main", "refs/heads/release/*"},
+			RequiredStatusChecks: []string{"ci/build"},
+		}},
+	})
+
+	cases := []struct {
+		name        string
+		repo        string
+		ref         string
+		statusCheck string
+		allowed     bool
+	}{
+		{"matching repo and ref with no status check required", "myorg/widgets", "refs/heads/main", "", true},
+		{"matching repo and release ref glob", "myorg/widgets", "refs/heads/release/1.0", "", true},
+		{"matching repo and ref with required status check satisfied", "myorg/widgets", "refs/heads/main", "ci/build", true},
+		{"matching repo and ref with required status check unsatisfied", "myorg/widgets", "refs/heads/main", "ci/lint", false},
+		{"repo does not match any rule", "otherorg/widgets", "refs/heads/main", "", false},
+		{"repo matches but ref does not", "myorg/widgets", "refs/heads/feature/x", "", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			allowed, reason := policy.Evaluate(tc.repo, tc.ref, tc.statusCheck)
+			if allowed != tc.allowed {
+				t.Fatalf("Evaluate(%q, %q, %q) = %v, %q; want allowed=%v", tc.repo, tc.ref, tc.statusCheck, allowed, reason, tc.allowed)
+			}
+			if !allowed && reason == "" {
+				t.Fatalf("Evaluate(%q, %q, %q) rejected with no reason", tc.repo, tc.ref, tc.statusCheck)
+			}
+		})
+	}
+}
+
+func TestTrustPolicyEvaluateNoRulesAllowsEverything(t *testing.T) {
+	policy := NewTrustPolicy(TrustPolicyConfig{})
+
+	allowed, reason := policy.Evaluate("anyorg/anyrepo", "refs/heads/anything", "")
+	if !allowed {
+		t.Fatalf("expected a rule-less policy to allow everything, got rejected: %q", reason)
+	}
+}
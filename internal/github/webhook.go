@@ -1,11 +1,10 @@
 package github
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"mime"
 	"net/http"
 	"strings"
 
@@ -19,10 +18,35 @@ import (
 )
 
 type WebhookWorker struct {
-	publishers  []Publisher
-	secretToken []byte
+	publishers []Publisher
+	providers  map[string]Provider
 
 	Source SourceConfig
+
+	// App, when set, mints an installation token for each github event of a
+	// typedEventKinds kind that carries an installation ID (other event
+	// kinds get no token) and attaches it to the published message
+	// as the "github-installation-token" header, so publishers can call
+	// the GitHub API as the app without re-authenticating. It is
+	// optional: workers which only forward events don't need it.
+	App *GitHubAppClient
+
+	// Trust, when set, gates publishing on the event's repo+ref matching an
+	// allowlist. It only applies to the github provider, the only one
+	// whose events carry a single well-defined repo+ref. A nil Trust
+	// allows everything.
+	Trust *TrustPolicy
+
+	// auditPublisher receives a RawMessage on the "github:rejected_webhook"
+	// topic when Trust rejects an event. It is separate from publishers so operators can route
+	// audit trails differently from the main event stream.
+	auditPublisher Publisher
+
+	// Dedup, when set, skips publishing github deliveries that have
+	// already been published, so GitHub's automatic webhook redelivery doesn't
+	// cause duplicate downstream events. A nil Dedup publishes every
+	// delivery unconditionally.
+	Dedup *DeliveryDedup
 }
 
 type SourceConfig struct {
@@ -35,40 +59,61 @@ type Publisher interface {
 	PublisherID() string
 }
 
-func NewWebhookWorker(secretToken string, source SourceConfig, publishers ...Publisher) (*WebhookWorker, error) {
+func NewWebhookWorker(secretToken string, source SourceConfig, app *GitHubAppClient, trust *TrustPolicy, auditPublisher Publisher, publishers ...Publisher) (*WebhookWorker, error) {
 	return &WebhookWorker{
-		secretToken: []byte(secretToken),
-		publishers:  publishers,
-		Source:      source,
+		publishers: publishers,
+		providers: map[string]Provider{
+			githubProviderName: NewGitHubProvider(secretToken),
+		},
+		Source:         source,
+		App:            app,
+		Trust:          trust,
+		auditPublisher: auditPublisher,
 	}, nil
 }
 
+// RegisterProvider adds or replaces the provider used for requests routed
+// to "/webhook/{name}". The built-in "github" provider is registered by
+// NewWebhookWorker; use this to add others, e.g. "gitlab" or "gitea".
+func (ww *WebhookWorker) RegisterProvider(name string, provider Provider) {
+	ww.providers[name] = provider
+}
+
+// typedEventKinds are the github webhook event names which are decoded into
+// their typed go-github structs before publishing, so malformed payloads
+// for these kinds are rejected with a 400 rather than forwarded blindly.
+// Other event kinds are still accepted and published as opaque RawMessages.
+//
+// messaging_tpb doesn't yet define per-kind github messages to route typed
+// payloads to, so the decoded event is re-marshalled back into a
+// RawMessage; the win today is validation and a stable topic per kind, with
+// a typed publish ready to slot in once those messages exist upstream.
+var typedEventKinds = map[string]bool{
+	"push":                      true,
+	"installation":              true,
+	"installation_repositories": true,
+	"check_run":                 true,
+	"check_suite":               true,
+}
+
 func (ww *WebhookWorker) HandleLambda(ctx context.Context, request *events.APIGatewayV2HTTPRequest) (*events.APIGatewayV2HTTPResponse, error) {
-	header := &http.Header{}
+	header := http.Header{}
 	for k, v := range request.Headers {
 		header.Add(k, v)
 	}
 
-	signature := header.Get(github.SHA256SignatureHeader)
-	if signature == "" {
-		signature = header.Get(github.SHA1SignatureHeader)
-	}
-
-	deliveryID := header.Get(github.DeliveryIDHeader)
-	if deliveryID == "" {
+	providerName := providerFromRequest(request)
+	provider, ok := ww.providers[providerName]
+	if !ok {
 		return &events.APIGatewayV2HTTPResponse{
-			StatusCode: http.StatusBadRequest,
-			Body:       "missing delivery ID",
+			StatusCode: http.StatusNotFound,
+			Body:       fmt.Sprintf("unknown webhook provider %q", providerName),
 		}, nil
 	}
 
-	contentType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
-	if err != nil {
-		return nil, fmt.Errorf("parse media type from '%s': %w", header.Get("Content-Type"), err)
-	}
-
 	bodyBytes := []byte(request.Body)
 	if request.IsBase64Encoded {
+		var err error
 		bodyBytes, err = base64.StdEncoding.DecodeString(request.Body)
 		if err != nil {
 			return nil, fmt.Errorf("decoding body: %w", err)
@@ -77,22 +122,238 @@ func (ww *WebhookWorker) HandleLambda(ctx context.Context, request *events.APIGa
 
 	log.WithField(ctx, "body", string(bodyBytes)).Debug("Received body")
 
-	bodyReader := bytes.NewReader(bodyBytes)
-
-	verifiedPayload, err := github.ValidatePayloadFromBody(contentType, bodyReader, signature, ww.secretToken)
+	verifiedPayload, err := provider.VerifySignature(header, bodyBytes)
 	if err != nil {
 		return nil, fmt.Errorf("validating payload: %w", err)
 	}
 
+	eventKind := provider.EventName(header)
+	payload := verifiedPayload
+
+	headers := map[string]string{"event_type": eventKind}
+
+	var deliveryID string
+	if providerName == githubProviderName {
+		deliveryID = header.Get(github.DeliveryIDHeader)
+		if deliveryID == "" {
+			return &events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusBadRequest,
+				Body:       "missing delivery ID",
+			}, nil
+		}
+
+		if ww.Dedup != nil {
+			duplicate, err := ww.Dedup.CheckAndRecord(ctx, deliveryID)
+			if err != nil {
+				return nil, fmt.Errorf("checking delivery %s for duplicates: %w", deliveryID, err)
+			}
+			if duplicate {
+				return &events.APIGatewayV2HTTPResponse{
+					StatusCode: http.StatusOK,
+					Body:       "duplicate, already delivered",
+				}, nil
+			}
+		}
+
+		var resp *events.APIGatewayV2HTTPResponse
+		payload, resp, err = ww.handleGitHubEvent(ctx, eventKind, verifiedPayload, headers)
+		if err != nil {
+			return nil, err
+		}
+		if resp != nil {
+			return resp, nil
+		}
+	}
+
 	msg := &messaging_tpb.RawMessage{
-		Topic:   fmt.Sprintf("github:%s", header.Get("X-GitHub-Event")),
-		Payload: verifiedPayload,
+		Topic:   provider.NormalizeTopic(eventKind),
+		Payload: payload,
+	}
+
+	resp, err := ww.publish(ctx, msg, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	if providerName == githubProviderName && ww.Dedup != nil {
+		if err := ww.Dedup.MarkCommitted(ctx, deliveryID); err != nil {
+			return nil, fmt.Errorf("committing delivery %s: %w", deliveryID, err)
+		}
+	}
+
+	return resp, nil
+}
+
+// providerFromRequest determines the provider name from the "/webhook/{provider}"
+// path prefix. When the route has no provider path parameter (e.g. an older
+// deployment with a fixed "/webhook" route), it defaults to "github" so
+// existing single-provider deployments keep working unchanged.
+func providerFromRequest(request *events.APIGatewayV2HTTPRequest) string {
+	if name, ok := request.PathParameters["provider"]; ok && name != "" {
+		return name
+	}
+	if segments := strings.Split(strings.Trim(request.RawPath, "/"), "/"); len(segments) >= 2 && segments[len(segments)-2] == "webhook" {
+		return segments[len(segments)-1]
+	}
+	return githubProviderName
+}
+
+// handleGitHubEvent applies the github provider's typed-event decoding and
+// trust policy check. It returns the payload to publish, or a non-nil
+// response when the request should be rejected without publishing. When the
+// event carries a single well-defined repo+ref, it is recorded in headers
+// so downstream publishers (e.g. SNS filter policies) can route on it.
+func (ww *WebhookWorker) handleGitHubEvent(ctx context.Context, eventKind string, verifiedPayload []byte, headers map[string]string) ([]byte, *events.APIGatewayV2HTTPResponse, error) {
+	payload := verifiedPayload
+	var typedEvent interface{}
+	if typedEventKinds[eventKind] {
+		var err error
+		typedEvent, err = github.ParseWebHook(eventKind, verifiedPayload)
+		if err != nil {
+			return nil, &events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusBadRequest,
+				Body:       fmt.Sprintf("parsing %s event: %s", eventKind, err),
+			}, nil
+		}
+		payload, err = json.Marshal(typedEvent)
+		if err != nil {
+			return nil, nil, fmt.Errorf("re-marshalling %s event: %w", eventKind, err)
+		}
+	}
+
+	repo, ref, statusCheck, ok := trustSubject(typedEvent)
+	if ok {
+		headers["repo"] = repo
+		headers["ref"] = ref
+		if owner, _, found := strings.Cut(repo, "/"); found {
+			headers["owner"] = owner
+		}
+	}
+
+	if ww.App != nil {
+		if instID, instOK := installationID(typedEvent); instOK {
+			token, err := ww.App.InstallationToken(ctx, instID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("minting installation token for installation %d: %w", instID, err)
+			}
+			headers["github-installation-token"] = token
+		}
+	}
+
+	if ww.Trust != nil && ok {
+		if allowed, reason := ww.Trust.Evaluate(repo, ref, statusCheck); !allowed {
+			resp, err := ww.rejectWebhook(ctx, eventKind, repo, ref, reason)
+			return nil, resp, err
+		}
+	}
+
+	return payload, nil, nil
+}
+
+// trustSubject extracts the repo, ref and (if applicable) status check name
+// from a decoded event, for trust policy evaluation. ok is false for event
+// kinds with no single repo+ref to evaluate, e.g. installation events.
+func trustSubject(event interface{}) (repo, ref, statusCheck string, ok bool) {
+	switch event := event.(type) {
+	case *github.PushEvent:
+		if event.Repo == nil || event.Repo.FullName == nil || event.Ref == nil {
+			return "", "", "", false
+		}
+		return *event.Repo.FullName, *event.Ref, "", true
+	case *github.CheckSuiteEvent:
+		if event.Repo == nil || event.Repo.FullName == nil || event.CheckSuite == nil || event.CheckSuite.HeadBranch == nil {
+			return "", "", "", false
+		}
+		return *event.Repo.FullName, "refs/heads/" + *event.CheckSuite.HeadBranch, "", true
+	case *github.CheckRunEvent:
+		if event.Repo == nil || event.Repo.FullName == nil || event.CheckRun == nil || event.CheckRun.CheckSuite == nil || event.CheckRun.CheckSuite.HeadBranch == nil {
+			return "", "", "", false
+		}
+		return *event.Repo.FullName, "refs/heads/" + *event.CheckRun.CheckSuite.HeadBranch, event.CheckRun.GetName(), true
+	default:
+		return "", "", "", false
+	}
+}
+
+// installationID extracts the GitHub App installation ID from a decoded
+// event, so InstallationToken can mint a token scoped to it. ok is false
+// for event kinds with no installation, or events delivered without an
+// App installed (e.g. a plain OAuth webhook).
+func installationID(event interface{}) (id int64, ok bool) {
+	var installation *github.Installation
+	switch event := event.(type) {
+	case *github.PushEvent:
+		installation = event.Installation
+	case *github.InstallationEvent:
+		installation = event.Installation
+	case *github.InstallationRepositoriesEvent:
+		installation = event.Installation
+	case *github.CheckRunEvent:
+		installation = event.Installation
+	case *github.CheckSuiteEvent:
+		installation = event.Installation
+	default:
+		return 0, false
+	}
+
+	if installation == nil || installation.ID == nil {
+		return 0, false
 	}
+	return *installation.ID, true
+}
+
+// rejectWebhook records an audit message (if an audit publisher is
+// configured) and responds 403 with a structured reason. The audit message
+// is a RawMessage on the "github:rejected_webhook" topic whose JSON payload
+// holds the event kind, repo, ref and reason; it is not a typed message.
+func (ww *WebhookWorker) rejectWebhook(ctx context.Context, eventKind, repo, ref, reason string) (*events.APIGatewayV2HTTPResponse, error) {
+	log.WithFields(ctx, map[string]interface{}{
+		"eventKind": eventKind,
+		"repo":      repo,
+		"ref":       ref,
+		"reason":    reason,
+	}).Info("Rejected webhook by trust policy")
 
-	return ww.publish(ctx, msg)
+	if ww.auditPublisher != nil {
+		auditPayload, err := json.Marshal(map[string]string{
+			"eventKind": eventKind,
+			"repo":      repo,
+			"ref":       ref,
+			"reason":    reason,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("marshalling rejected webhook audit: %w", err)
+		}
+
+		msg := &messaging_tpb.RawMessage{
+			Topic:   "github:rejected_webhook",
+			Payload: auditPayload,
+		}
+		wireMessage, err := o5msg.WrapMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		wireMessage.SourceApp = ww.Source.SourceApp
+		wireMessage.SourceEnv = ww.Source.SourceEnv
+		wireMessage.DestinationTopic = msg.Topic
+
+		if err := ww.auditPublisher.Publish(ctx, wireMessage); err != nil {
+			return nil, fmt.Errorf("publishing rejected webhook audit: %w", err)
+		}
+	}
+
+	body, err := json.Marshal(map[string]string{"reason": reason})
+	if err != nil {
+		return nil, fmt.Errorf("marshalling rejection response: %w", err)
+	}
+
+	return &events.APIGatewayV2HTTPResponse{
+		StatusCode: http.StatusForbidden,
+		Body:       string(body),
+	}, nil
 }
 
-func (ww *WebhookWorker) publish(ctx context.Context, msg *messaging_tpb.RawMessage) (*events.APIGatewayV2HTTPResponse, error) {
+func (ww *WebhookWorker) publish(ctx context.Context, msg *messaging_tpb.RawMessage, headers map[string]string) (*events.APIGatewayV2HTTPResponse, error) {
 
 	wireMessage, err := o5msg.WrapMessage(msg)
 	if err != nil {
@@ -102,6 +363,7 @@ func (ww *WebhookWorker) publish(ctx context.Context, msg *messaging_tpb.RawMess
 	wireMessage.SourceApp = ww.Source.SourceApp
 	wireMessage.SourceEnv = ww.Source.SourceEnv
 	wireMessage.DestinationTopic = msg.Topic
+	wireMessage.Headers = headers
 
 	output := make([]string, 0, len(ww.publishers))
 	output = append(output, fmt.Sprintf("O5 Message ID: %s", wireMessage.MessageId))